@@ -0,0 +1,100 @@
+// Package ast declares the expression tree produced by the parser, along
+// with a Walk/Inspect API and a debug printer, in the style of go/ast.
+package ast
+
+import "github.com/oezg/smart-calculator-go/token"
+
+// Expr is implemented by every node of an expression tree.
+type Expr interface {
+	Pos() token.Pos
+}
+
+// IntLit is an integer literal, e.g. 42.
+type IntLit struct {
+	ValuePos token.Pos
+	Value    int
+}
+
+// Ident is an identifier, e.g. a variable name.
+type Ident struct {
+	NamePos token.Pos
+	Name    string
+}
+
+// UnaryExpr is a unary operator applied to an operand, e.g. -x.
+type UnaryExpr struct {
+	OpPos token.Pos
+	Op    token.Token
+	X     Expr
+}
+
+// BinaryExpr is a binary operator applied to two operands, e.g. x + y.
+type BinaryExpr struct {
+	X     Expr
+	OpPos token.Pos
+	Op    token.Token
+	Y     Expr
+}
+
+// CallExpr is a function call, e.g. f(x, y).
+type CallExpr struct {
+	Fun    *Ident
+	Lparen token.Pos
+	Args   []Expr
+	Rparen token.Pos
+}
+
+func (x *IntLit) Pos() token.Pos     { return x.ValuePos }
+func (x *Ident) Pos() token.Pos      { return x.NamePos }
+func (x *UnaryExpr) Pos() token.Pos  { return x.OpPos }
+func (x *BinaryExpr) Pos() token.Pos { return x.X.Pos() }
+func (x *CallExpr) Pos() token.Pos   { return x.Fun.Pos() }
+
+// Visitor's Visit method is invoked for every node encountered by Walk. If
+// the result w is not nil, Walk visits each of the children of node with
+// the visitor w.
+type Visitor interface {
+	Visit(node Expr) (w Visitor)
+}
+
+// Walk traverses an expression tree in depth-first order: it starts by
+// calling v.Visit(node); node must not be nil. If the visitor w returned by
+// v.Visit(node) is not nil, Walk is invoked recursively with visitor w for
+// each of the children of node.
+func Walk(v Visitor, node Expr) {
+	if v = v.Visit(node); v == nil {
+		return
+	}
+	switch n := node.(type) {
+	case *IntLit, *Ident:
+		// no children
+	case *UnaryExpr:
+		Walk(v, n.X)
+	case *BinaryExpr:
+		Walk(v, n.X)
+		Walk(v, n.Y)
+	case *CallExpr:
+		for _, arg := range n.Args {
+			Walk(v, arg)
+		}
+	default:
+		panic("ast.Walk: unexpected node type")
+	}
+}
+
+type inspector func(Expr) bool
+
+func (f inspector) Visit(node Expr) Visitor {
+	if f(node) {
+		return f
+	}
+	return nil
+}
+
+// Inspect traverses an expression tree in depth-first order: it starts by
+// calling f(node); node must not be nil. If f returns true, Inspect invokes
+// f recursively for each of the children of node, followed by a call of
+// f(nil).
+func Inspect(node Expr, f func(Expr) bool) {
+	Walk(inspector(f), node)
+}
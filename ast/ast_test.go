@@ -0,0 +1,68 @@
+package ast
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/oezg/smart-calculator-go/token"
+)
+
+// f(x) + 2, built by hand since this package doesn't depend on the parser.
+func sampleExpr() Expr {
+	return &BinaryExpr{
+		X: &CallExpr{
+			Fun:  &Ident{Name: "f"},
+			Args: []Expr{&Ident{Name: "x"}},
+		},
+		Op: token.PLUS,
+		Y:  &IntLit{Value: 2},
+	}
+}
+
+func TestInspectVisitsEveryNode(t *testing.T) {
+	var kinds []string
+	Inspect(sampleExpr(), func(n Expr) bool {
+		switch n.(type) {
+		case nil:
+			return true
+		case *BinaryExpr:
+			kinds = append(kinds, "BinaryExpr")
+		case *CallExpr:
+			kinds = append(kinds, "CallExpr")
+		case *Ident:
+			kinds = append(kinds, "Ident")
+		case *IntLit:
+			kinds = append(kinds, "IntLit")
+		}
+		return true
+	})
+	want := []string{"BinaryExpr", "CallExpr", "Ident", "IntLit"}
+	if len(kinds) != len(want) {
+		t.Fatalf("visited %v, want %v", kinds, want)
+	}
+	for i, k := range want {
+		if kinds[i] != k {
+			t.Errorf("kinds[%d] = %q, want %q", i, kinds[i], k)
+		}
+	}
+}
+
+func TestWalkPanicsOnUnknownNode(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Fatal("Walk did not panic on an unknown node type")
+		}
+	}()
+	Walk(inspector(func(Expr) bool { return true }), struct{ Expr }{})
+}
+
+func TestFdump(t *testing.T) {
+	var buf strings.Builder
+	if err := Fdump(&buf, sampleExpr()); err != nil {
+		t.Fatalf("Fdump error: %v", err)
+	}
+	want := "BinaryExpr +\n.  CallExpr f\n.  .  Ident \"x\"\n.  IntLit 2\n"
+	if got := buf.String(); got != want {
+		t.Errorf("Fdump =\n%s\nwant\n%s", got, want)
+	}
+}
@@ -0,0 +1,53 @@
+package ast
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Fdump writes a textual, indented representation of node to w for
+// debugging (cf. the /con command, which instead prints the postfix form).
+func Fdump(w io.Writer, node Expr) error {
+	p := dumper{w: w}
+	p.dump(node, 0)
+	return p.err
+}
+
+type dumper struct {
+	w   io.Writer
+	err error
+}
+
+func (p *dumper) printf(depth int, format string, args ...any) {
+	if p.err != nil {
+		return
+	}
+	line := strings.Repeat(".  ", depth) + fmt.Sprintf(format, args...) + "\n"
+	_, p.err = io.WriteString(p.w, line)
+}
+
+func (p *dumper) dump(node Expr, depth int) {
+	switch n := node.(type) {
+	case nil:
+		p.printf(depth, "nil")
+	case *IntLit:
+		p.printf(depth, "IntLit %d", n.Value)
+	case *Ident:
+		p.printf(depth, "Ident %q", n.Name)
+	case *UnaryExpr:
+		p.printf(depth, "UnaryExpr %s", n.Op)
+		p.dump(n.X, depth+1)
+	case *BinaryExpr:
+		p.printf(depth, "BinaryExpr %s", n.Op)
+		p.dump(n.X, depth+1)
+		p.dump(n.Y, depth+1)
+	case *CallExpr:
+		p.printf(depth, "CallExpr %s", n.Fun.Name)
+		for _, arg := range n.Args {
+			p.dump(arg, depth+1)
+		}
+	default:
+		p.printf(depth, "<unknown node>")
+	}
+}
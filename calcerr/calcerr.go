@@ -0,0 +1,45 @@
+// Package calcerr defines the calculator's diagnostic error type: a typed,
+// position-carrying replacement for the old UNKNOWN/INVALID/EMPTY string
+// sentinels.
+package calcerr
+
+import "github.com/oezg/smart-calculator-go/token"
+
+// Kind classifies a diagnostic.
+type Kind int
+
+const (
+	EmptyExpr Kind = iota
+	UnknownIdent
+	InvalidToken
+	UnbalancedParen
+	DivByZero
+	ArityMismatch
+	// StackUnderflow is kept for parity with the postfix evaluator this
+	// replaced; the tree-walking evaluator never produces it, since the
+	// parser cannot build a BinaryExpr with a missing operand.
+	StackUnderflow
+	// InvalidIdent reports an assignment target, function name, or
+	// parameter name that isn't a valid identifier. It's raised before
+	// the text is ever handed to the parser, so it carries its own
+	// position into the assignee rather than one from a token.
+	InvalidIdent
+)
+
+// Error is a diagnostic produced while parsing or evaluating an expression.
+type Error struct {
+	Kind    Kind
+	Pos     token.Pos
+	Snippet string
+	Msg     string
+}
+
+func (e *Error) Error() string {
+	return e.Msg
+}
+
+// New builds an Error of the given kind at pos, quoting snippet as the
+// offending text.
+func New(kind Kind, pos token.Pos, snippet, msg string) *Error {
+	return &Error{Kind: kind, Pos: pos, Snippet: snippet, Msg: msg}
+}
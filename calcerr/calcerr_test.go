@@ -0,0 +1,21 @@
+package calcerr
+
+import (
+	"testing"
+
+	"github.com/oezg/smart-calculator-go/token"
+)
+
+func TestNew(t *testing.T) {
+	pos := token.Pos{Offset: 4, Line: 1, Col: 5}
+	err := New(DivByZero, pos, "/", "division by zero")
+	if err.Kind != DivByZero {
+		t.Errorf("Kind = %v, want DivByZero", err.Kind)
+	}
+	if err.Pos != pos {
+		t.Errorf("Pos = %v, want %v", err.Pos, pos)
+	}
+	if err.Error() != "division by zero" {
+		t.Errorf("Error() = %q, want %q", err.Error(), "division by zero")
+	}
+}
@@ -0,0 +1,221 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/oezg/smart-calculator-go/ast"
+	"github.com/oezg/smart-calculator-go/calcerr"
+	"github.com/oezg/smart-calculator-go/parser"
+)
+
+// Function is a user-defined function: a parsed expression closed over its
+// parameter names, evaluated with those names bound to the call's
+// arguments.
+type Function struct {
+	Params []Identifier
+	Body   ast.Expr
+}
+
+// builtin is a function implemented in Go rather than parsed from user
+// input. Built-ins are looked up through the same call mechanism as
+// user-defined functions, once those don't match.
+type builtin func(args []Value) (Value, error)
+
+var builtins = map[Identifier]builtin{
+	"abs":  builtinAbs,
+	"min":  builtinMin,
+	"max":  builtinMax,
+	"gcd":  builtinGcd,
+	"sqrt": builtinSqrt,
+}
+
+// arityError reports that fun was called with got arguments but expects
+// want.
+func arityError(fun *ast.Ident, want, got int) error {
+	return calcerr.New(calcerr.ArityMismatch, fun.Pos(), fun.Name,
+		fmt.Sprintf("%q expects %d argument(s), got %d", fun.Name, want, got))
+}
+
+func builtinAbs(args []Value) (Value, error) {
+	if args[0] < 0 {
+		return -args[0], nil
+	}
+	return args[0], nil
+}
+
+func builtinMin(args []Value) (Value, error) {
+	if args[0] < args[1] {
+		return args[0], nil
+	}
+	return args[1], nil
+}
+
+func builtinMax(args []Value) (Value, error) {
+	if args[0] > args[1] {
+		return args[0], nil
+	}
+	return args[1], nil
+}
+
+func builtinGcd(args []Value) (Value, error) {
+	a, b := args[0], args[1]
+	if a < 0 {
+		a = -a
+	}
+	if b < 0 {
+		b = -b
+	}
+	for b != 0 {
+		a, b = b, a%b
+	}
+	return a, nil
+}
+
+func builtinSqrt(args []Value) (Value, error) {
+	return Value(math.Sqrt(float64(args[0]))), nil
+}
+
+// builtinArity lists how many arguments each built-in expects, so arity is
+// checked uniformly before a builtin body ever sees its arguments.
+var builtinArity = map[Identifier]int{
+	"abs":  1,
+	"min":  2,
+	"max":  2,
+	"gcd":  2,
+	"sqrt": 1,
+}
+
+// evaluateCall evaluates a call to fun with already-evaluated args,
+// preferring a user-defined function over a built-in of the same name.
+func (s *Session) evaluateCall(fun *ast.Ident, args []Value) (Value, error) {
+	name := Identifier(fun.Name)
+	if fn, ok := s.functions[name]; ok {
+		if len(fn.Params) != len(args) {
+			return 0, arityError(fun, len(fn.Params), len(args))
+		}
+		scope := make(map[Identifier]Value, len(fn.Params))
+		for i, param := range fn.Params {
+			scope[param] = args[i]
+		}
+		return s.evaluate(fn.Body, scope)
+	}
+	if fn, ok := builtins[name]; ok {
+		if want := builtinArity[name]; want != len(args) {
+			return 0, arityError(fun, want, len(args))
+		}
+		return fn(args)
+	}
+	return 0, calcerr.New(calcerr.UnknownIdent, fun.Pos(), fun.Name, fmt.Sprintf("unknown function %q", fun.Name))
+}
+
+// isFunctionSignature reports whether assignee looks like "name(params)",
+// i.e. a function definition rather than a variable assignment.
+func isFunctionSignature(assignee string) (name string, paramsText string, ok bool) {
+	if !strings.HasSuffix(assignee, ")") {
+		return "", "", false
+	}
+	open := strings.Index(assignee, "(")
+	if open <= 0 {
+		return "", "", false
+	}
+	return assignee[:open], assignee[open+1 : len(assignee)-1], true
+}
+
+// defineFunction parses "name(p1, p2, ...)" and body into a Function and
+// stores it under name. assignee is the original "name(p1, p2, ...)" text,
+// kept around only so an invalid identifier can be reported at its actual
+// position within it.
+func (s *Session) defineFunction(assignee, name, paramsText, body string) error {
+	if !isIdentifier(name) {
+		return identifierError(0, name)
+	}
+	var params []Identifier
+	if trimmedParams := strings.TrimSpace(paramsText); trimmedParams != "" {
+		for _, param := range strings.Split(trimmedParams, ",") {
+			param = strings.TrimSpace(param)
+			if !isIdentifier(param) {
+				return identifierError(strings.Index(assignee, param), param)
+			}
+			params = append(params, Identifier(param))
+		}
+	}
+	expr, err := parser.ParseWithTable(body, s.table)
+	if err != nil {
+		return err
+	}
+	s.functions[Identifier(name)] = Function{Params: params, Body: expr}
+	return nil
+}
+
+func (s *Session) sortFunctionNames() (identifiers []Identifier) {
+	identifiers = make([]Identifier, 0, len(s.functions))
+	for identifier := range s.functions {
+		identifiers = Push(identifiers, identifier)
+	}
+	sort.Slice(identifiers, func(i, j int) bool {
+		return identifiers[i] < identifiers[j]
+	})
+	return
+}
+
+func (s *Session) printFunctions(writer io.Writer) {
+	for _, name := range s.sortFunctionNames() {
+		fn := s.functions[name]
+		params := make([]string, len(fn.Params))
+		for i, param := range fn.Params {
+			params[i] = string(param)
+		}
+		_, err := fmt.Fprintf(writer, "%s(%s) = %s\n", name, strings.Join(params, ", "), toInfix(fn.Body))
+		if err != nil {
+			fmt.Println(err)
+		}
+	}
+}
+
+// toInfix renders expr back to infix notation, parenthesizing only where
+// precedence requires it, so a function body can be written out and read
+// back unchanged. It always parenthesizes against parser.StandardTable,
+// regardless of the session's active precedence profile, so /write output
+// stays stable even while experimenting with /prec.
+func toInfix(expr ast.Expr) string {
+	return infix(expr, 0)
+}
+
+func infix(expr ast.Expr, minPrec int) string {
+	switch n := expr.(type) {
+	case *ast.IntLit:
+		return strconv.Itoa(n.Value)
+	case *ast.Ident:
+		return n.Name
+	case *ast.CallExpr:
+		args := make([]string, len(n.Args))
+		for i, arg := range n.Args {
+			args[i] = infix(arg, 0)
+		}
+		return n.Fun.Name + "(" + strings.Join(args, ", ") + ")"
+	case *ast.UnaryExpr:
+		return n.Op.String() + infix(n.X, maxPrec)
+	case *ast.BinaryExpr:
+		info := parser.StandardTable[n.Op]
+		prec := int(info.Prec)
+		leftMin, rightMin := prec, prec+1
+		if info.Assoc == parser.RightAssociative {
+			leftMin, rightMin = prec+1, prec
+		}
+		s := infix(n.X, leftMin) + " " + n.Op.String() + " " + infix(n.Y, rightMin)
+		if prec < minPrec {
+			return "(" + s + ")"
+		}
+		return s
+	}
+	return ""
+}
+
+// maxPrec is higher than any binary operator's precedence, so a binary
+// operand of a unary expression is always parenthesized.
+const maxPrec = 1 << 30
@@ -0,0 +1,150 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/oezg/smart-calculator-go/calcerr"
+)
+
+func mustEval(t *testing.T, s *Session, text string) {
+	t.Helper()
+	if _, err := s.evalLine(text); err != nil {
+		t.Fatalf("evalLine(%q) error: %v", text, err)
+	}
+}
+
+func TestEvaluateCallArityMismatch(t *testing.T) {
+	s := NewSession()
+	mustEval(t, s, "f(x, y) = x + y")
+
+	_, err := s.evalLine("f(1)")
+	var ce *calcerr.Error
+	if !errors.As(err, &ce) || ce.Kind != calcerr.ArityMismatch {
+		t.Fatalf("f(1) error = %v, want ArityMismatch", err)
+	}
+
+	_, err = s.evalLine("abs(1, 2)")
+	if !errors.As(err, &ce) || ce.Kind != calcerr.ArityMismatch {
+		t.Fatalf("abs(1, 2) error = %v, want ArityMismatch", err)
+	}
+}
+
+func TestEvaluateCallUnknownFunction(t *testing.T) {
+	s := NewSession()
+	_, err := s.evalLine("nope(1)")
+	var ce *calcerr.Error
+	if !errors.As(err, &ce) || ce.Kind != calcerr.UnknownIdent {
+		t.Fatalf("nope(1) error = %v, want UnknownIdent", err)
+	}
+}
+
+func TestBuiltins(t *testing.T) {
+	tests := []struct {
+		expr string
+		want Value
+	}{
+		{"abs(-5)", 5},
+		{"abs(5)", 5},
+		{"min(3, 7)", 3},
+		{"max(3, 7)", 7},
+		{"gcd(12, 18)", 6},
+		{"sqrt(9)", 3},
+	}
+	s := NewSession()
+	for _, tt := range tests {
+		value, err := s.evaluateExpression(tt.expr)
+		if err != nil {
+			t.Fatalf("evaluateExpression(%q) error: %v", tt.expr, err)
+		}
+		if value != tt.want {
+			t.Errorf("evaluateExpression(%q) = %d, want %d", tt.expr, value, tt.want)
+		}
+	}
+}
+
+func TestUserFunctionCall(t *testing.T) {
+	s := NewSession()
+	mustEval(t, s, "f(x, y) = x^2 + y")
+
+	value, err := s.evaluateExpression("f(3, 4)")
+	if err != nil {
+		t.Fatalf("evaluateExpression error: %v", err)
+	}
+	if value != 13 {
+		t.Errorf("f(3, 4) = %d, want 13", value)
+	}
+}
+
+func TestWriteReadFunctionsRoundTrip(t *testing.T) {
+	s := NewSession()
+	mustEval(t, s, "x = 5")
+	mustEval(t, s, "f(a, b) = a * b + x")
+
+	path := filepath.Join(t.TempDir(), "vars.txt")
+	s.writeVariables(path)
+
+	var buf bytes.Buffer
+	s.mu.Lock()
+	s.printFunctions(&buf)
+	s.mu.Unlock()
+	if got, want := buf.String(), "f(a, b) = a * b + x\n"; got != want {
+		t.Fatalf("printFunctions = %q, want %q", got, want)
+	}
+
+	fresh := NewSession()
+	fresh.readVariables(path)
+
+	value, err := fresh.evaluateExpression("f(2, 3)")
+	if err != nil {
+		t.Fatalf("evaluateExpression after read error: %v", err)
+	}
+	if value != 11 {
+		t.Errorf("f(2, 3) after round-trip = %d, want 11", value)
+	}
+}
+
+func TestReadVariablesReportsLineNumberedErrors(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "bad.txt")
+	content := "x = 1\ny = 1 / 0\n"
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+
+	s := NewSession()
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatalf("Pipe: %v", err)
+	}
+	stdout := os.Stdout
+	os.Stdout = w
+	s.readVariables(path)
+	w.Close()
+	os.Stdout = stdout
+
+	var buf bytes.Buffer
+	buf.ReadFrom(r)
+	output := buf.String()
+	wantLine := path + ":2: division by zero"
+	if !bytes.Contains([]byte(output), []byte(wantLine)) {
+		t.Fatalf("readVariables output = %q, want it to contain %q", output, wantLine)
+	}
+}
+
+func TestDefineFunctionInvalidIdentifiers(t *testing.T) {
+	s := NewSession()
+
+	_, err := s.evalLine("1f(x) = x")
+	if !isInvalidIdent(err) {
+		t.Fatalf("1f(x) = x error = %v, want InvalidIdent", err)
+	}
+
+	_, err = s.evalLine("g(x, 2y) = x + 2y")
+	var ce *calcerr.Error
+	if !errors.As(err, &ce) || ce.Kind != calcerr.InvalidIdent || ce.Snippet != "2y" {
+		t.Fatalf("g(x, 2y) error = %v, want InvalidIdent for \"2y\"", err)
+	}
+}
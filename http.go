@@ -0,0 +1,165 @@
+package main
+
+import (
+	"embed"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+
+	"github.com/oezg/smart-calculator-go/parser"
+)
+
+//go:embed static/playground.html
+var playgroundHTML embed.FS
+
+// startServer binds addr and serves the calculator playground backed by
+// session in the background. It returns once the listener is ready, so
+// "/serve" and "-serve" can report a bind failure immediately instead of
+// discovering it asynchronously.
+func startServer(addr string, session *Session) error {
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return err
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/", handleIndex)
+	mux.HandleFunc("/eval", session.handleEval)
+	mux.HandleFunc("/vars", session.handleVars)
+	mux.HandleFunc("/vars/", session.handleVar)
+	mux.HandleFunc("/convert", session.handleConvert)
+	fmt.Printf("Serving calculator playground on http://%s\n", listener.Addr())
+	go http.Serve(listener, mux)
+	return nil
+}
+
+func handleIndex(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path != "/" {
+		http.NotFound(w, r)
+		return
+	}
+	data, err := playgroundHTML.ReadFile("static/playground.html")
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Write(data)
+}
+
+type evalRequest struct {
+	Expr string           `json:"expr"`
+	Vars map[string]Value `json:"vars"`
+}
+
+type evalResponse struct {
+	Result *Value `json:"result,omitempty"`
+	Error  string `json:"error,omitempty"`
+}
+
+// handleEval evaluates an expression against vars layered on top of the
+// session's own variables, without modifying the session.
+func (s *Session) handleEval(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	var req evalRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	scope := make(map[Identifier]Value, len(req.Vars))
+	for name, value := range req.Vars {
+		scope[Identifier(name)] = value
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	expr, err := parser.ParseWithTable(req.Expr, s.table)
+	if err != nil {
+		writeJSON(w, evalResponse{Error: message(err)})
+		return
+	}
+	value, err := s.evaluate(expr, scope)
+	if err != nil {
+		writeJSON(w, evalResponse{Error: message(err)})
+		return
+	}
+	writeJSON(w, evalResponse{Result: &value})
+}
+
+// handleVars serves GET /vars, listing the session's variables.
+func (s *Session) handleVars(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	s.mu.Lock()
+	vars := make(map[string]Value, len(s.memory))
+	for name, value := range s.memory {
+		vars[string(name)] = value
+	}
+	s.mu.Unlock()
+	writeJSON(w, vars)
+}
+
+// handleVar serves PUT /vars/{name}, setting a single variable from a JSON
+// body of the form {"value": N}.
+func (s *Session) handleVar(w http.ResponseWriter, r *http.Request) {
+	name := strings.TrimPrefix(r.URL.Path, "/vars/")
+	if r.Method != http.MethodPut || name == "" {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	var req struct {
+		Value Value `json:"value"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	s.mu.Lock()
+	s.memory[Identifier(name)] = req.Value
+	s.mu.Unlock()
+	writeJSON(w, map[string]Value{name: req.Value})
+}
+
+type convertResponse struct {
+	Postfix string `json:"postfix,omitempty"`
+	Error   string `json:"error,omitempty"`
+}
+
+// handleConvert serves POST /convert, rendering an expression in postfix
+// notation.
+func (s *Session) handleConvert(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	var req evalRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	s.mu.Lock()
+	expr, err := parser.ParseWithTable(req.Expr, s.table)
+	s.mu.Unlock()
+	if err != nil {
+		writeJSON(w, convertResponse{Error: message(err)})
+		return
+	}
+	writeJSON(w, convertResponse{Postfix: toPostfix(expr)})
+}
+
+// message extracts the human-readable text of err, unwrapping a
+// *calcerr.Error if there is one.
+func message(err error) string {
+	msg, _ := errMessage(err)
+	return msg
+}
+
+func writeJSON(w http.ResponseWriter, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(v)
+}
@@ -0,0 +1,118 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func doRequest(t *testing.T, handler http.HandlerFunc, method, target string, body any) *httptest.ResponseRecorder {
+	t.Helper()
+	var reader *bytes.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			t.Fatalf("Marshal: %v", err)
+		}
+		reader = bytes.NewReader(data)
+	} else {
+		reader = bytes.NewReader(nil)
+	}
+	req := httptest.NewRequest(method, target, reader)
+	rec := httptest.NewRecorder()
+	handler(rec, req)
+	return rec
+}
+
+func TestHandleEval(t *testing.T) {
+	s := NewSession()
+	mustEval(t, s, "x = 10")
+
+	rec := doRequest(t, s.handleEval, http.MethodPost, "/eval", evalRequest{Expr: "x + y", Vars: map[string]Value{"y": 5}})
+	var resp evalResponse
+	if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+		t.Fatalf("decode response: %v", err)
+	}
+	if resp.Error != "" || resp.Result == nil || *resp.Result != 15 {
+		t.Fatalf("handleEval response = %+v, want result 15", resp)
+	}
+
+	// The session's own variables must be untouched by the request's vars.
+	if _, ok := s.memory["y"]; ok {
+		t.Errorf("handleEval leaked request var %q into session memory", "y")
+	}
+}
+
+func TestHandleEvalDivByZero(t *testing.T) {
+	s := NewSession()
+	rec := doRequest(t, s.handleEval, http.MethodPost, "/eval", evalRequest{Expr: "1 / 0"})
+	var resp evalResponse
+	if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+		t.Fatalf("decode response: %v", err)
+	}
+	if resp.Error != "division by zero" {
+		t.Fatalf("handleEval error = %q, want %q", resp.Error, "division by zero")
+	}
+}
+
+func TestHandleEvalWrongMethod(t *testing.T) {
+	s := NewSession()
+	rec := doRequest(t, s.handleEval, http.MethodGet, "/eval", nil)
+	if rec.Code != http.StatusMethodNotAllowed {
+		t.Errorf("status = %d, want %d", rec.Code, http.StatusMethodNotAllowed)
+	}
+}
+
+func TestHandleVars(t *testing.T) {
+	s := NewSession()
+	mustEval(t, s, "x = 1")
+	mustEval(t, s, "y = 2")
+
+	rec := doRequest(t, s.handleVars, http.MethodGet, "/vars", nil)
+	var vars map[string]Value
+	if err := json.NewDecoder(rec.Body).Decode(&vars); err != nil {
+		t.Fatalf("decode response: %v", err)
+	}
+	if vars["x"] != 1 || vars["y"] != 2 {
+		t.Fatalf("handleVars response = %+v, want x=1 y=2", vars)
+	}
+}
+
+func TestHandleVar(t *testing.T) {
+	s := NewSession()
+	rec := doRequest(t, s.handleVar, http.MethodPut, "/vars/z", struct {
+		Value Value `json:"value"`
+	}{Value: 42})
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200, body %s", rec.Code, rec.Body.String())
+	}
+	if s.memory["z"] != 42 {
+		t.Errorf("memory[z] = %d, want 42", s.memory["z"])
+	}
+}
+
+func TestHandleConvert(t *testing.T) {
+	s := NewSession()
+	rec := doRequest(t, s.handleConvert, http.MethodPost, "/convert", evalRequest{Expr: "2 + 3 * 4"})
+	var resp convertResponse
+	if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+		t.Fatalf("decode response: %v", err)
+	}
+	if resp.Postfix != "2 3 4 * +" {
+		t.Fatalf("handleConvert postfix = %q, want %q", resp.Postfix, "2 3 4 * +")
+	}
+}
+
+func TestHandleConvertParseError(t *testing.T) {
+	s := NewSession()
+	rec := doRequest(t, s.handleConvert, http.MethodPost, "/convert", evalRequest{Expr: "2 +"})
+	var resp convertResponse
+	if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+		t.Fatalf("decode response: %v", err)
+	}
+	if resp.Postfix != "" || resp.Error == "" {
+		t.Fatalf("handleConvert response = %+v, want an error and no postfix", resp)
+	}
+}
@@ -3,6 +3,7 @@ package main
 import (
 	"bufio"
 	"errors"
+	"flag"
 	"fmt"
 	"io"
 	"math"
@@ -11,19 +12,24 @@ import (
 	"strconv"
 	"strings"
 	"unicode"
+
+	"github.com/oezg/smart-calculator-go/ast"
+	"github.com/oezg/smart-calculator-go/calcerr"
+	"github.com/oezg/smart-calculator-go/parser"
+	"github.com/oezg/smart-calculator-go/token"
 )
 
 const (
-	UNKNOWN = "Unknown variable "
-	INVALID = "Invalid "
-	EMPTY   = "empty expression"
-	HELP    = `Smart calculator commands:
+	HELP = `Smart calculator commands:
 /clear	clears all variables
 /vars	prints variables
 /del	deletes variables (space separated)
 /con	converts infix to postfix notation
 /read	reads given file and updates variables
 /write	writes variables to given file
+/history	prints recent input, or "/history clear" to clear it
+/serve	starts an HTTP playground server on the given address
+/prec	switches precedence profile: standard, flat, addfirst, or "load path"
 /help	prints help
 /exit	exits program
 
@@ -31,43 +37,38 @@ Smart calculator operations:
 (   )   +   -   *   /   %   ^
 
 Smart calculator supports only Latin characters for variables
-Smart calculator supports only integers for numerical types.`
+Smart calculator supports only integers for numerical types.
+
+Define a function with f(x, y) = x^2 + y, then call it as f(3, 4).
+Built-in functions: abs, min, max, gcd, sqrt.`
 )
 
-var memory = make(map[Identifier]Value)
+// exiting is set by the "/exit" command to unwind the REPL loop in runRepl
+// instead of calling os.Exit, so liner can restore the terminal and the
+// session history still gets saved.
+var exiting bool
 
 type (
-	Identifier    string
-	Value         int
-	Operator      string
-	ValueStack    []Value
-	OperatorStack []Operator
-	Expression    []Term
+	Identifier string
+	Value      int
 )
 
-type Term struct {
-	Value      Value
-	IsOperator bool
-	Operator   Operator
-}
-
-type RawTerm struct {
-	isIdentifier, isValue, isOperator, closed bool
-	Text                                      string
-}
-
 func main() {
-	fmt.Println("+-+-+   Welcome to Smart Calculator   */*/*")
-	fmt.Println("Enter a command or start calculation or type '/help'")
-	scanner := bufio.NewScanner(os.Stdin)
-	for scanner.Scan() {
-		handleCommand(scanner.Text())
+	addr := flag.String("serve", "", "start an HTTP playground server on this address alongside the REPL")
+	flag.Parse()
+
+	session := NewSession()
+	if *addr != "" {
+		if err := startServer(*addr, session); err != nil {
+			fmt.Println(err)
+		}
 	}
+	runRepl(session)
 }
 
-func handleCommand(text string) {
+func (s *Session) handleCommand(text string) {
 	if !strings.HasPrefix(text, "/") {
-		handleAssignment(text)
+		s.handleAssignment(text)
 		return
 	}
 	commands := strings.SplitN(text[1:], " ", 2)
@@ -75,350 +76,187 @@ func handleCommand(text string) {
 	switch command {
 	case "exit":
 		fmt.Println("Bye!")
-		os.Exit(0)
+		exiting = true
 	case "help":
 		fmt.Println(HELP)
 	case "vars":
-		printVariables(os.Stdout)
+		s.mu.Lock()
+		s.printVariables(os.Stdout)
+		s.printFunctions(os.Stdout)
+		s.mu.Unlock()
 	case "clear":
-		memory = make(map[Identifier]Value)
+		s.mu.Lock()
+		s.memory = make(map[Identifier]Value)
+		s.mu.Unlock()
 	case "con":
 		if IsEmpty(commands[1:]) {
 			return
 		}
-		convertExpression(commands[1])
+		s.convertExpression(commands[1])
 	case "del":
 		if IsEmpty(commands[1:]) {
 			return
 		}
-		deleteVariables(commands[1])
+		s.deleteVariables(commands[1])
 	case "read":
 		if IsEmpty(commands[1:]) {
 			return
 		}
-		readVariables(commands[1])
+		s.readVariables(commands[1])
 	case "write":
 		if IsEmpty(commands[1:]) {
 			return
 		}
-		writeVariables(commands[1])
-	default:
-		fmt.Println("Unknown command")
-	}
-}
-
-func handleAssignment(text string) {
-	if !strings.Contains(text, "=") {
-		handleExpression(text)
-		return
-	}
-	assignmentSides := strings.SplitN(text, "=", 2)
-	assignee := strings.TrimSpace(assignmentSides[0])
-	assigned := strings.TrimSpace(assignmentSides[1])
-	if !isIdentifier(assignee) {
-		fmt.Println("Invalid identifier")
-		return
-	}
-	if result, err := evaluateExpression(assigned); err == nil {
-		memory[Identifier(assignee)] = result
-	} else {
-		must(err, "assignment")
-	}
-}
-
-func handleExpression(text string) {
-	text = strings.TrimSpace(text)
-	if result, err := evaluateExpression(text); err == nil {
-		fmt.Println(result)
-	} else {
-		must(err, "expression")
-	}
-}
-
-func evaluateExpression(text string) (value Value, err error) {
-	var expression Expression
-	if expression, err = convert2Postfix(text); err != nil {
-		return
-	}
-	value, err = expression.Evaluate()
-	return
-}
-
-func convert2Postfix(text string) (expression Expression, err error) {
-	if 0 == len(text) {
-		err = errors.New(EMPTY)
-		return
-	}
-	reader := strings.NewReader(text)
-	scanner := bufio.NewScanner(reader)
-	scanner.Split(bufio.ScanRunes)
-	var stack OperatorStack
-	var currentTerm, lastTerm RawTerm
-	var term Term
-	for scanner.Scan() {
-		currentTerm.Close(lastTerm, scanner.Text())
-		if currentTerm.closed {
-			if term, err = validate(currentTerm); err != nil {
-				return
-			}
-			if err = expression.Grow(&stack, term); err != nil {
-				return
-			}
-			lastTerm, currentTerm = currentTerm, RawTerm{}
+		s.writeVariables(commands[1])
+	case "history":
+		if !IsEmpty(commands[1:]) && strings.TrimSpace(commands[1]) == "clear" {
+			clearHistory()
+			return
 		}
-		if err = currentTerm.Extend(scanner.Text()); err != nil {
+		printHistory()
+	case "serve":
+		if IsEmpty(commands[1:]) {
 			return
 		}
-	}
-	if term, err = validate(currentTerm); err != nil {
-		return
-	}
-	if err = expression.Grow(&stack, term); err != nil {
-		return
-	}
-	for !IsEmpty(stack) {
-		var operator Operator
-		stack, operator = Pop(stack)
-		expression.Add(Term{Operator: operator, IsOperator: true})
-	}
-	return
-}
-
-func (expression *Expression) Evaluate() (value Value, err error) {
-	if IsEmpty(*expression) {
-		err = errors.New(EMPTY)
-		return
-	}
-	var stack ValueStack
-	var value1, value2, result Value
-	for _, term := range *expression {
-		if !term.IsOperator {
-			stack = Push(stack, term.Value)
-			continue
+		if err := startServer(strings.TrimSpace(commands[1]), s); err != nil {
+			fmt.Println(err)
 		}
-		stack, value1 = Pop(stack)
-		stack, value2 = Pop(stack)
-		if stack == nil {
-			err = errors.New(INVALID)
+	case "prec":
+		if IsEmpty(commands[1:]) {
+			return
 		}
-		result = term.Operator.Operate(value2, value1)
-		stack = Push(stack, result)
+		s.setPrecedence(commands[1])
+	default:
+		fmt.Println("Unknown command")
 	}
-	value = Peek(stack)
-	return
 }
 
-func (expression *Expression) Add(terms ...Term) {
-	for _, term := range terms {
-		*expression = Push(*expression, term)
+func (s *Session) handleAssignment(text string) {
+	if snippet, err := s.evalLine(text); err != nil {
+		must(err, snippet)
 	}
 }
 
-func (expression *Expression) Grow(stack *OperatorStack, term Term) error {
-	if term.IsOperator {
-		poppedOperators, err := stack.Update(term.Operator)
+// evalLine runs a single line of input: either a "name = expr" / "f(params)
+// = expr" definition, or a bare expression to evaluate and print. It
+// returns the text actually parsed, for diagnostics to point into, so
+// callers can report errors uniformly whether they came from the REPL or
+// from a file read with /read.
+func (s *Session) evalLine(text string) (snippet string, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !strings.Contains(text, "=") {
+		snippet = strings.TrimSpace(text)
+		value, err := s.evaluateExpression(snippet)
 		if err != nil {
-			return err
+			return snippet, err
 		}
-		expression.Add(poppedOperators...)
-	} else {
-		expression.Add(term)
+		fmt.Println(value)
+		return snippet, nil
 	}
-	return nil
-}
-
-func (expression *Expression) String() string {
-	terms := make([]string, 0, len(*expression))
-	for _, term := range *expression {
-		if term.IsOperator {
-			terms = Push(terms, string(term.Operator))
-		} else {
-			terms = Push(terms, strconv.Itoa(int(term.Value)))
+	assignmentSides := strings.SplitN(text, "=", 2)
+	assignee := strings.TrimSpace(assignmentSides[0])
+	snippet = strings.TrimSpace(assignmentSides[1])
+	if name, paramsText, ok := isFunctionSignature(assignee); ok {
+		if err := s.defineFunction(assignee, name, paramsText, snippet); err != nil {
+			if isInvalidIdent(err) {
+				return assignee, err
+			}
+			return snippet, err
 		}
+		return snippet, nil
 	}
-	return strings.Join(terms, " ")
+	if !isIdentifier(assignee) {
+		return assignee, identifierError(0, assignee)
+	}
+	result, err := s.evaluateExpression(snippet)
+	if err != nil {
+		return snippet, err
+	}
+	s.memory[Identifier(assignee)] = result
+	return snippet, nil
 }
 
-func (operator Operator) Operate(value1, value2 Value) (result Value) {
-	switch operator {
-	case "+":
-		result = value1 + value2
-	case "-":
-		result = value1 - value2
-	case "*":
-		result = value1 * value2
-	case "/":
-		result = value1 / value2
-	case "%":
-		result = value1 % value2
-	case "^":
-		result = Value(math.Pow(float64(value1), float64(value2)))
+// evaluateExpression parses text into an ast.Expr and evaluates it against
+// the session's variables.
+func (s *Session) evaluateExpression(text string) (value Value, err error) {
+	expr, err := parser.ParseWithTable(text, s.table)
+	if err != nil {
+		return
 	}
-	return
+	return s.evaluate(expr, nil)
 }
 
-func validate(term RawTerm) (validated Term, err error) {
-	if term.isOperator {
-		if operator, ok := isOperator(term.Text); ok {
-			validated = Term{Operator: operator, IsOperator: true}
-		} else {
-			err = errors.New(INVALID)
+// evaluate walks expr, computing its value against scope (a call's bound
+// parameters, checked first) and falling back to the session's variables.
+func (s *Session) evaluate(expr ast.Expr, scope map[Identifier]Value) (Value, error) {
+	switch n := expr.(type) {
+	case *ast.IntLit:
+		return Value(n.Value), nil
+	case *ast.Ident:
+		if value, ok := scope[Identifier(n.Name)]; ok {
+			return value, nil
+		}
+		if value, ok := s.memory[Identifier(n.Name)]; ok {
+			return value, nil
 		}
-	} else if term.isValue {
-		if value, ok := isNumber(term.Text); ok {
-			validated = Term{Value: value}
-		} else {
-			err = errors.New(INVALID)
+		return 0, calcerr.New(calcerr.UnknownIdent, n.Pos(), n.Name, fmt.Sprintf("unknown variable %q", n.Name))
+	case *ast.UnaryExpr:
+		x, err := s.evaluate(n.X, scope)
+		if err != nil {
+			return 0, err
 		}
-	} else if term.isIdentifier {
-		if value, ok := memory[Identifier(term.Text)]; ok {
-			validated = Term{Value: value}
-		} else if isIdentifier(term.Text) {
-			err = errors.New(UNKNOWN)
-		} else {
-			err = errors.New(INVALID)
+		if n.Op == token.MINUS {
+			return -x, nil
 		}
-	}
-	return
-}
-
-func Precedence(operator Operator) (precedence int8) {
-	switch operator {
-	case "+", "-":
-		precedence = 1
-	case "*", "/", "%":
-		precedence = 2
-	case "^":
-		precedence = 3
-	}
-	return
-}
-
-func IsEmpty[T comparable](list []T) bool {
-	return len(list) == 0
-}
-
-func Peek[T comparable](stack []T) T {
-	var t T
-	if IsEmpty(stack) {
-		return t
-	}
-	return stack[len(stack)-1]
-}
-
-func Push[T comparable](stack []T, element T) []T {
-	return append(stack, element)
-}
-
-func Pop[T comparable](stack []T) ([]T, T) {
-	if IsEmpty(stack) {
-		var t T
-		return nil, t
-	}
-	last := len(stack) - 1
-	return stack[:last], stack[last]
-}
-
-func (stack *OperatorStack) Update(operator Operator) (operators []Term, err error) {
-	if IsEmpty(*stack) || "(" == operator || "(" == Peek(*stack) {
-		*stack = Push(*stack, operator)
-		return
-	}
-	if ")" == operator {
-		for !IsEmpty(*stack) {
-			tempStack, topOfStack := Pop(*stack)
-			*stack = tempStack
-			if "(" == topOfStack {
-				return
-			} else {
-				operators = Push(operators, Term{Operator: topOfStack, IsOperator: true})
-			}
+		return x, nil
+	case *ast.BinaryExpr:
+		x, err := s.evaluate(n.X, scope)
+		if err != nil {
+			return 0, err
 		}
-		err = errors.New(INVALID)
-		return
-	}
-	if Precedence(Peek(*stack)) < Precedence(operator) {
-		*stack = Push(*stack, operator)
-		return
-	}
-	for !IsEmpty(*stack) {
-		topOfStack := Peek(*stack)
-		if "(" == topOfStack || Precedence(topOfStack) < Precedence(operator) {
-			*stack = Push(*stack, operator)
-			return
-		} else {
-			*stack, topOfStack = Pop(*stack)
-			operators = append(operators, Term{Operator: topOfStack, IsOperator: true})
+		y, err := s.evaluate(n.Y, scope)
+		if err != nil {
+			return 0, err
 		}
-	}
-	*stack = Push(*stack, operator)
-	return
-}
-
-func (term *RawTerm) Close(last RawTerm, char string) {
-	switch {
-	case " " == char:
-		term.closed = true
-	case term.isValue:
-		_, ok := isNumber(char)
-		term.closed = !ok
-	case term.isOperator:
-		_, ok := isNumber(char)
-		if ok && (term.Text == "+" || term.Text == "-") {
-			term.closed = last.isIdentifier || last.isValue
-		} else if strings.HasSuffix(term.Text, "+") || strings.HasSuffix(term.Text, "-") {
-			term.closed = !(char == "+" || char == "-")
-		} else {
-			term.closed = true
+		return operate(n.Op, n.OpPos, x, y)
+	case *ast.CallExpr:
+		args := make([]Value, len(n.Args))
+		for i, arg := range n.Args {
+			value, err := s.evaluate(arg, scope)
+			if err != nil {
+				return 0, err
+			}
+			args[i] = value
 		}
-	case term.isIdentifier:
-		term.closed = !isIdentifier(char)
+		return s.evaluateCall(n.Fun, args)
+	default:
+		return 0, calcerr.New(calcerr.InvalidToken, expr.Pos(), "", "invalid expression")
 	}
 }
 
-func (term *RawTerm) Extend(char string) (err error) {
-	switch {
-	case " " == char:
-		return
-	case term.isValue:
-	case term.isOperator:
-		if _, ok := isNumber(char); ok {
-			term.isOperator = false
-			term.isValue = true
+func operate(op token.Token, pos token.Pos, x, y Value) (Value, error) {
+	switch op {
+	case token.PLUS:
+		return x + y, nil
+	case token.MINUS:
+		return x - y, nil
+	case token.STAR:
+		return x * y, nil
+	case token.SLASH:
+		if y == 0 {
+			return 0, calcerr.New(calcerr.DivByZero, pos, op.String(), "division by zero")
 		}
-	case term.isIdentifier:
-	default:
-		if _, ok := isNumber(char); ok {
-			term.isValue = true
-		} else if _, ok = isOperator(char); ok {
-			term.isOperator = true
-		} else if isIdentifier(char) {
-			term.isIdentifier = true
-		} else {
-			err = errors.New(INVALID)
+		return x / y, nil
+	case token.PERCENT:
+		if y == 0 {
+			return 0, calcerr.New(calcerr.DivByZero, pos, op.String(), "division by zero")
 		}
+		return x % y, nil
+	case token.CARET:
+		return Value(math.Pow(float64(x), float64(y))), nil
 	}
-	term.Text += char
-	return
-}
-
-func isNumber(text string) (Value, bool) {
-	number, err := strconv.Atoi(text)
-	if err != nil {
-		return Value(0), false
-	}
-	return Value(number), true
-}
-
-func isOperator(text string) (Operator, bool) {
-	switch text {
-	case "*", "/", "^", "%", "(", ")", "+", "-":
-		return Operator(text), true
-	}
-	if minus, err := plusMinus(text); err == nil {
-		return Operator(minus), true
-	}
-	return "", false
+	return 0, nil
 }
 
 func isIdentifier(text string) bool {
@@ -430,40 +268,48 @@ func isIdentifier(text string) bool {
 	return true
 }
 
-func plusMinus(text string) (string, error) {
-	var negative bool
-	for _, symbol := range text {
-		if symbol == '+' {
-			continue
+// identifierError reports that text isn't a valid identifier, positioned at
+// offset within whatever larger text the caller goes on to print (e.g. the
+// assignee), so must can still put a caret under it.
+func identifierError(offset int, text string) error {
+	return calcerr.New(calcerr.InvalidIdent, token.Pos{Offset: offset, Line: 1, Col: offset + 1}, text,
+		fmt.Sprintf("invalid identifier %q", text))
+}
+
+// isInvalidIdent reports whether err is an identifierError, i.e. diagnoses
+// the assignee rather than the parsed body.
+func isInvalidIdent(err error) bool {
+	var ce *calcerr.Error
+	return errors.As(err, &ce) && ce.Kind == calcerr.InvalidIdent
+}
+
+// must prints err unless it's an empty-expression diagnostic, which the
+// REPL has always treated as "nothing to do" rather than an error. For a
+// *calcerr.Error it echoes the parsed text with a caret under the
+// offending position, e.g.:
+//
+//	2 + * 3
+//	    ^ invalid token '*': expected operand
+func must(err error, text string) {
+	var ce *calcerr.Error
+	if errors.As(err, &ce) {
+		if ce.Kind == calcerr.EmptyExpr {
+			return
 		}
-		if symbol == '-' {
-			negative = !negative
-		} else {
-			return "", errors.New(INVALID)
+		if ce.Pos.Col > 0 {
+			fmt.Println(text)
+			fmt.Println(strings.Repeat(" ", ce.Pos.Col-1) + "^ " + ce.Msg)
+			return
 		}
+		fmt.Println(ce.Msg)
+		return
 	}
-	if negative {
-		return "-", nil
-	}
-	return "+", nil
-}
-
-func must(err error, statement string) {
-	if err.Error() != EMPTY {
-		printError(err.Error(), statement)
-	}
-}
-
-func printError(message, statement string) {
-	if message == INVALID {
-		message += statement
-	}
-	fmt.Println(message)
+	fmt.Println(err)
 }
 
-func sortVariables() (identifiers []Identifier) {
-	identifiers = make([]Identifier, 0, len(memory))
-	for identifier := range memory {
+func (s *Session) sortVariables() (identifiers []Identifier) {
+	identifiers = make([]Identifier, 0, len(s.memory))
+	for identifier := range s.memory {
 		identifiers = Push(identifiers, identifier)
 	}
 	sort.Slice(identifiers, func(i, j int) bool {
@@ -472,23 +318,26 @@ func sortVariables() (identifiers []Identifier) {
 	return
 }
 
-func printVariables(writer io.Writer) {
-	for _, identifier := range sortVariables() {
-		_, err := fmt.Fprintf(writer, "%s = %d\n", identifier, memory[identifier])
+func (s *Session) printVariables(writer io.Writer) {
+	for _, identifier := range s.sortVariables() {
+		_, err := fmt.Fprintf(writer, "%s = %d\n", identifier, s.memory[identifier])
 		if err != nil {
 			fmt.Println(err)
 		}
 	}
 }
 
-func deleteVariables(text string) {
+func (s *Session) deleteVariables(text string) {
 	arguments := strings.Split(text, " ")
+	s.mu.Lock()
+	defer s.mu.Unlock()
 	for _, variable := range arguments {
-		delete(memory, Identifier(variable))
+		delete(s.memory, Identifier(variable))
+		delete(s.functions, Identifier(variable))
 	}
 }
 
-func readVariables(text string) {
+func (s *Session) readVariables(text string) {
 	file, err := os.Open(text)
 	if err != nil {
 		fmt.Println(err)
@@ -500,12 +349,29 @@ func readVariables(text string) {
 		}
 	}(file)
 	scanner := bufio.NewScanner(file)
+	lineNo := 0
 	for scanner.Scan() {
-		handleAssignment(scanner.Text())
+		lineNo++
+		if _, err := s.evalLine(scanner.Text()); err != nil {
+			if msg, report := errMessage(err); report {
+				fmt.Printf("%s:%d: %s\n", text, lineNo, msg)
+			}
+		}
 	}
 }
 
-func writeVariables(text string) {
+// errMessage extracts the human-readable message from err, unwrapping a
+// *calcerr.Error if there is one. report is false for an empty-expression
+// diagnostic, which a blank line produces and which isn't worth reporting.
+func errMessage(err error) (msg string, report bool) {
+	var ce *calcerr.Error
+	if errors.As(err, &ce) {
+		return ce.Msg, ce.Kind != calcerr.EmptyExpr
+	}
+	return err.Error(), true
+}
+
+func (s *Session) writeVariables(text string) {
 	file, err := os.Create(text)
 	if err != nil {
 		fmt.Println(err)
@@ -516,15 +382,50 @@ func writeVariables(text string) {
 			fmt.Println(err)
 		}
 	}(file)
-	printVariables(file)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.printVariables(file)
+	s.printFunctions(file)
 }
 
-func convertExpression(text string) {
+func (s *Session) convertExpression(text string) {
 	infixExpression := strings.TrimSpace(text)
-	postfixExpression, err := convert2Postfix(infixExpression)
+	s.mu.Lock()
+	expr, err := parser.ParseWithTable(infixExpression, s.table)
+	s.mu.Unlock()
 	if err != nil {
-		fmt.Println(err)
+		must(err, infixExpression)
 		return
 	}
-	fmt.Println(postfixExpression.String())
+	fmt.Println(toPostfix(expr))
+}
+
+// toPostfix walks expr and renders it in postfix (reverse Polish) notation,
+// the way convert2Postfix used to before expressions became trees.
+func toPostfix(expr ast.Expr) string {
+	var terms []string
+	switch n := expr.(type) {
+	case *ast.IntLit:
+		terms = Push(terms, strconv.Itoa(n.Value))
+	case *ast.Ident:
+		terms = Push(terms, n.Name)
+	case *ast.UnaryExpr:
+		terms = Push(terms, toPostfix(n.X), n.Op.String())
+	case *ast.BinaryExpr:
+		terms = Push(terms, toPostfix(n.X), toPostfix(n.Y), n.Op.String())
+	case *ast.CallExpr:
+		for _, arg := range n.Args {
+			terms = Push(terms, toPostfix(arg))
+		}
+		terms = Push(terms, n.Fun.Name)
+	}
+	return strings.Join(terms, " ")
+}
+
+func IsEmpty[T comparable](list []T) bool {
+	return len(list) == 0
+}
+
+func Push[T any](stack []T, elements ...T) []T {
+	return append(stack, elements...)
 }
@@ -0,0 +1,101 @@
+package main
+
+import (
+	"errors"
+	"sync"
+	"testing"
+
+	"github.com/oezg/smart-calculator-go/calcerr"
+)
+
+func TestEvaluateExpressionArithmetic(t *testing.T) {
+	tests := []struct {
+		expr string
+		want Value
+	}{
+		{"2 + 3 * 4", 14},
+		{"(2 + 3) * 4", 20},
+		{"2 ^ 2 ^ 3", 256},
+		{"-5 + 3", -2},
+		{"7 % 2", 1},
+	}
+	s := NewSession()
+	for _, tt := range tests {
+		value, err := s.evaluateExpression(tt.expr)
+		if err != nil {
+			t.Fatalf("evaluateExpression(%q) error: %v", tt.expr, err)
+		}
+		if value != tt.want {
+			t.Errorf("evaluateExpression(%q) = %d, want %d", tt.expr, value, tt.want)
+		}
+	}
+}
+
+func TestEvaluateExpressionDivByZero(t *testing.T) {
+	s := NewSession()
+	for _, expr := range []string{"1 / 0", "1 % 0"} {
+		_, err := s.evaluateExpression(expr)
+		var ce *calcerr.Error
+		if !errors.As(err, &ce) || ce.Kind != calcerr.DivByZero {
+			t.Fatalf("evaluateExpression(%q) error = %v, want DivByZero", expr, err)
+		}
+	}
+}
+
+func TestEvalLineAssignmentAndLookup(t *testing.T) {
+	s := NewSession()
+	mustEval(t, s, "x = 5")
+	value, err := s.evaluateExpression("x * 2")
+	if err != nil {
+		t.Fatalf("evaluateExpression error: %v", err)
+	}
+	if value != 10 {
+		t.Errorf("x * 2 = %d, want 10", value)
+	}
+}
+
+func TestEvalLineUnknownVariable(t *testing.T) {
+	s := NewSession()
+	_, err := s.evalLine("undefined + 1")
+	var ce *calcerr.Error
+	if !errors.As(err, &ce) || ce.Kind != calcerr.UnknownIdent {
+		t.Fatalf("evalLine error = %v, want UnknownIdent", err)
+	}
+}
+
+func TestDeleteVariables(t *testing.T) {
+	s := NewSession()
+	mustEval(t, s, "x = 1")
+	mustEval(t, s, "y = 2")
+	s.deleteVariables("x y")
+	if len(s.memory) != 0 {
+		t.Errorf("memory after delete = %v, want empty", s.memory)
+	}
+}
+
+// TestSessionConcurrentAccess exercises the REPL-side and HTTP-side paths
+// that share Session.mu together, the scenario -serve runs in practice.
+// It's only useful under "go test -race", but should stay race-clean
+// either way.
+func TestSessionConcurrentAccess(t *testing.T) {
+	s := NewSession()
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(3)
+		go func() {
+			defer wg.Done()
+			s.evalLine("x = 1 + 2")
+		}()
+		go func() {
+			defer wg.Done()
+			s.knownIdentifiers()
+		}()
+		go func() {
+			defer wg.Done()
+			s.mu.Lock()
+			_ = len(s.memory)
+			s.mu.Unlock()
+		}()
+	}
+	wg.Wait()
+}
@@ -0,0 +1,174 @@
+// Package parser parses calculator expressions into an ast.Expr tree. It
+// consumes a scanner.Scanner and implements a small precedence-climbing
+// recursive descent parser.
+package parser
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/oezg/smart-calculator-go/ast"
+	"github.com/oezg/smart-calculator-go/calcerr"
+	"github.com/oezg/smart-calculator-go/scanner"
+	"github.com/oezg/smart-calculator-go/token"
+)
+
+// Parser holds the parsing state for a single expression.
+type Parser struct {
+	scan  *scanner.Scanner
+	table PrecedenceTable
+
+	pos token.Pos
+	tok token.Token
+	lit string
+}
+
+// Parse parses src as a single expression under StandardTable and returns
+// its ast.Expr tree.
+func Parse(src string) (ast.Expr, error) {
+	return ParseWithTable(src, StandardTable)
+}
+
+// ParseWithTable parses src as a single expression, using table to resolve
+// binary operator precedence and associativity.
+func ParseWithTable(src string, table PrecedenceTable) (ast.Expr, error) {
+	p := &Parser{scan: scanner.New(src), table: table}
+	p.next()
+	if p.tok == token.EOF {
+		return nil, calcerr.New(calcerr.EmptyExpr, p.pos, "", "empty expression")
+	}
+	expr, err := p.parseBinary(1)
+	if err != nil {
+		return nil, err
+	}
+	if p.tok != token.EOF {
+		return nil, p.errorf(calcerr.InvalidToken, "invalid token %q: expected operator", p.text())
+	}
+	return expr, nil
+}
+
+func (p *Parser) next() {
+	p.pos, p.tok, p.lit = p.scan.Scan()
+}
+
+// text returns the literal text of the current token, falling back to its
+// symbol for tokens the scanner doesn't record a literal for (operators,
+// parentheses, EOF).
+func (p *Parser) text() string {
+	if p.lit != "" {
+		return p.lit
+	}
+	return p.tok.String()
+}
+
+func (p *Parser) errorf(kind calcerr.Kind, format string, args ...any) *calcerr.Error {
+	return calcerr.New(kind, p.pos, p.text(), fmt.Sprintf(format, args...))
+}
+
+// parseBinary parses a sequence of unary expressions joined by binary
+// operators of at least minPrec precedence, as defined by p.table.
+func (p *Parser) parseBinary(minPrec int) (ast.Expr, error) {
+	left, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		info, ok := p.table[p.tok]
+		if !ok || int(info.Prec) < minPrec {
+			return left, nil
+		}
+		op, opPos := p.tok, p.pos
+		p.next()
+		next := int(info.Prec) + 1
+		if info.Assoc == RightAssociative {
+			next = int(info.Prec)
+		}
+		right, err := p.parseBinary(next)
+		if err != nil {
+			return nil, err
+		}
+		left = &ast.BinaryExpr{X: left, Op: op, OpPos: opPos, Y: right}
+	}
+}
+
+func (p *Parser) parseUnary() (ast.Expr, error) {
+	if p.tok == token.PLUS || p.tok == token.MINUS {
+		opPos, op := p.pos, p.tok
+		p.next()
+		x, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return &ast.UnaryExpr{OpPos: opPos, Op: op, X: x}, nil
+	}
+	return p.parsePrimary()
+}
+
+func (p *Parser) parsePrimary() (ast.Expr, error) {
+	switch p.tok {
+	case token.INT:
+		value, err := atoi(p.lit)
+		if err != nil {
+			return nil, p.errorf(calcerr.InvalidToken, "invalid integer literal %q: out of range", p.lit)
+		}
+		n := &ast.IntLit{ValuePos: p.pos, Value: value}
+		p.next()
+		return n, nil
+	case token.IDENT:
+		id := &ast.Ident{NamePos: p.pos, Name: p.lit}
+		p.next()
+		if p.tok != token.LPAREN {
+			return id, nil
+		}
+		return p.parseCall(id)
+	case token.LPAREN:
+		p.next()
+		x, err := p.parseBinary(1)
+		if err != nil {
+			return nil, err
+		}
+		if p.tok != token.RPAREN {
+			return nil, p.errorf(calcerr.UnbalancedParen, "unbalanced parenthesis")
+		}
+		p.next()
+		return x, nil
+	case token.ILLEGAL:
+		return nil, p.errorf(calcerr.InvalidToken, "invalid token %q", p.text())
+	default:
+		return nil, p.errorf(calcerr.InvalidToken, "invalid token %q: expected operand", p.text())
+	}
+}
+
+// parseCall parses the "(" arg {"," arg} ")" suffix of a call expression
+// whose function name id has already been consumed.
+func (p *Parser) parseCall(id *ast.Ident) (ast.Expr, error) {
+	lparen := p.pos
+	p.next()
+	var args []ast.Expr
+	if p.tok != token.RPAREN {
+		for {
+			arg, err := p.parseBinary(1)
+			if err != nil {
+				return nil, err
+			}
+			args = append(args, arg)
+			if p.tok != token.COMMA {
+				break
+			}
+			p.next()
+		}
+	}
+	if p.tok != token.RPAREN {
+		p.pos = lparen
+		return nil, p.errorf(calcerr.UnbalancedParen, "unbalanced parenthesis")
+	}
+	rparen := p.pos
+	p.next()
+	return &ast.CallExpr{Fun: id, Lparen: lparen, Args: args, Rparen: rparen}, nil
+}
+
+// atoi converts a scanned integer literal to an int, rejecting literals
+// that overflow int rather than silently wrapping.
+func atoi(lit string) (int, error) {
+	return strconv.Atoi(lit)
+}
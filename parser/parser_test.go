@@ -0,0 +1,89 @@
+package parser
+
+import (
+	"errors"
+	"strconv"
+	"testing"
+
+	"github.com/oezg/smart-calculator-go/ast"
+	"github.com/oezg/smart-calculator-go/calcerr"
+)
+
+func TestParsePostfix(t *testing.T) {
+	tests := []struct {
+		src  string
+		want string
+	}{
+		{"2 + 3 * 4", "2 3 4 * +"},
+		{"(2 + 3) * 4", "2 3 + 4 *"},
+		{"2 - 3 - 4", "2 3 - 4 -"},
+		{"2 ^ 2 ^ 3", "2 2 3 ^ ^"},
+		{"-1 + 2", "1 - 2 +"},
+		{"f(1, 2 + 3)", "1 2 3 + f"},
+	}
+	for _, tt := range tests {
+		expr, err := Parse(tt.src)
+		if err != nil {
+			t.Fatalf("Parse(%q) error: %v", tt.src, err)
+		}
+		if got := toPostfix(expr); got != tt.want {
+			t.Errorf("Parse(%q) = %q, want %q", tt.src, got, tt.want)
+		}
+	}
+}
+
+func TestParseWithTable(t *testing.T) {
+	expr, err := ParseWithTable("2 + 3 * 4", FlatTable)
+	if err != nil {
+		t.Fatalf("ParseWithTable error: %v", err)
+	}
+	if got, want := toPostfix(expr), "2 3 + 4 *"; got != want {
+		t.Errorf("under FlatTable, Parse(%q) = %q, want %q", "2 + 3 * 4", got, want)
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := []struct {
+		src  string
+		kind calcerr.Kind
+	}{
+		{"", calcerr.EmptyExpr},
+		{"2 +", calcerr.InvalidToken},
+		{"2 + * 3", calcerr.InvalidToken},
+		{"(2 + 3", calcerr.UnbalancedParen},
+		{"2 3", calcerr.InvalidToken},
+		{"99999999999999999999999", calcerr.InvalidToken},
+	}
+	for _, tt := range tests {
+		_, err := Parse(tt.src)
+		var ce *calcerr.Error
+		if !errors.As(err, &ce) {
+			t.Fatalf("Parse(%q) error = %v, want *calcerr.Error", tt.src, err)
+		}
+		if ce.Kind != tt.kind {
+			t.Errorf("Parse(%q) kind = %v, want %v", tt.src, ce.Kind, tt.kind)
+		}
+	}
+}
+
+// toPostfix renders expr in postfix notation for assertions, independent of
+// the toPostfix helper in package main.
+func toPostfix(expr ast.Expr) string {
+	switch n := expr.(type) {
+	case *ast.IntLit:
+		return strconv.Itoa(n.Value)
+	case *ast.Ident:
+		return n.Name
+	case *ast.UnaryExpr:
+		return toPostfix(n.X) + " " + n.Op.String()
+	case *ast.BinaryExpr:
+		return toPostfix(n.X) + " " + toPostfix(n.Y) + " " + n.Op.String()
+	case *ast.CallExpr:
+		s := ""
+		for _, arg := range n.Args {
+			s += toPostfix(arg) + " "
+		}
+		return s + n.Fun.Name
+	}
+	return ""
+}
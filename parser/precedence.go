@@ -0,0 +1,64 @@
+package parser
+
+import "github.com/oezg/smart-calculator-go/token"
+
+// Associativity describes which side a binary operator groups to when it
+// repeats, e.g. "a - b - c" is (a - b) - c under LeftAssociative.
+type Associativity int
+
+const (
+	LeftAssociative Associativity = iota
+	RightAssociative
+)
+
+// OpInfo is a binary operator's binding power and associativity, the two
+// facts parseBinary needs to decide how far to extend the right-hand side
+// of an expression before returning.
+type OpInfo struct {
+	Prec  int8
+	Assoc Associativity
+}
+
+// PrecedenceTable maps binary operator tokens to their OpInfo. A token
+// absent from the table is not treated as a binary operator by parseBinary.
+type PrecedenceTable map[token.Token]OpInfo
+
+// StandardTable is the precedence this calculator has always used: + and -
+// bind loosest, * / and % bind tighter, and ^ binds tightest of all and
+// associates right-to-left (so 2^2^3 is 2^(2^3)).
+var StandardTable = PrecedenceTable{
+	token.PLUS:    {1, LeftAssociative},
+	token.MINUS:   {1, LeftAssociative},
+	token.STAR:    {2, LeftAssociative},
+	token.SLASH:   {2, LeftAssociative},
+	token.PERCENT: {2, LeftAssociative},
+	token.CARET:   {3, RightAssociative},
+}
+
+// FlatTable gives every operator equal precedence, left-to-right, useful
+// for exercising parsing without precedence climbing kicking in.
+var FlatTable = PrecedenceTable{
+	token.PLUS:    {1, LeftAssociative},
+	token.MINUS:   {1, LeftAssociative},
+	token.STAR:    {1, LeftAssociative},
+	token.SLASH:   {1, LeftAssociative},
+	token.PERCENT: {1, LeftAssociative},
+	token.CARET:   {1, LeftAssociative},
+}
+
+// AddFirstTable inverts the usual rule: + and - bind tighter than * / and %.
+var AddFirstTable = PrecedenceTable{
+	token.PLUS:    {2, LeftAssociative},
+	token.MINUS:   {2, LeftAssociative},
+	token.STAR:    {1, LeftAssociative},
+	token.SLASH:   {1, LeftAssociative},
+	token.PERCENT: {1, LeftAssociative},
+	token.CARET:   {3, RightAssociative},
+}
+
+// Tables maps the names the "/prec" command accepts to their tables.
+var Tables = map[string]PrecedenceTable{
+	"standard": StandardTable,
+	"flat":     FlatTable,
+	"addfirst": AddFirstTable,
+}
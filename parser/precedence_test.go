@@ -0,0 +1,29 @@
+package parser
+
+import "testing"
+
+func TestTablesHasEveryProfile(t *testing.T) {
+	for _, name := range []string{"standard", "flat", "addfirst"} {
+		if _, ok := Tables[name]; !ok {
+			t.Errorf("Tables[%q] missing", name)
+		}
+	}
+}
+
+func TestFlatTableIsFlat(t *testing.T) {
+	for op, info := range FlatTable {
+		if info.Prec != 1 {
+			t.Errorf("FlatTable[%v].Prec = %d, want 1", op, info.Prec)
+		}
+	}
+}
+
+func TestAddFirstTablePrefersAddition(t *testing.T) {
+	expr, err := ParseWithTable("2 + 3 * 4", AddFirstTable)
+	if err != nil {
+		t.Fatalf("ParseWithTable error: %v", err)
+	}
+	if got, want := toPostfix(expr), "2 3 + 4 *"; got != want {
+		t.Errorf("under AddFirstTable, Parse(%q) = %q, want %q", "2 + 3 * 4", got, want)
+	}
+}
@@ -0,0 +1,88 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/oezg/smart-calculator-go/parser"
+	"github.com/oezg/smart-calculator-go/token"
+)
+
+// setPrecedence handles "/prec <name>" and "/prec load <path>", switching
+// the session's active PrecedenceTable.
+func (s *Session) setPrecedence(arg string) {
+	fields := strings.Fields(arg)
+	if len(fields) == 2 && fields[0] == "load" {
+		table, err := loadPrecedenceTable(fields[1])
+		if err != nil {
+			fmt.Println(err)
+			return
+		}
+		s.mu.Lock()
+		s.table = table
+		s.mu.Unlock()
+		return
+	}
+	table, ok := parser.Tables[strings.TrimSpace(arg)]
+	if !ok {
+		fmt.Printf("unknown precedence profile %q\n", arg)
+		return
+	}
+	s.mu.Lock()
+	s.table = table
+	s.mu.Unlock()
+}
+
+// loadPrecedenceTable reads a custom PrecedenceTable from path, one operator
+// per line: "<op> <precedence> <left|right>", e.g. "^ 4 right".
+func loadPrecedenceTable(path string) (parser.PrecedenceTable, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	table := make(parser.PrecedenceTable)
+	scanner := bufio.NewScanner(file)
+	lineNo := 0
+	for scanner.Scan() {
+		lineNo++
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 0 {
+			continue
+		}
+		if len(fields) != 3 {
+			return nil, fmt.Errorf(`%s:%d: expected "op precedence assoc"`, path, lineNo)
+		}
+		tok, ok := token.Lookup(fields[0])
+		if !ok {
+			return nil, fmt.Errorf("%s:%d: unknown operator %q", path, lineNo, fields[0])
+		}
+		prec, err := strconv.Atoi(fields[1])
+		if err != nil {
+			return nil, fmt.Errorf("%s:%d: invalid precedence %q", path, lineNo, fields[1])
+		}
+		assoc, err := parseAssociativity(fields[2])
+		if err != nil {
+			return nil, fmt.Errorf("%s:%d: %w", path, lineNo, err)
+		}
+		table[tok] = parser.OpInfo{Prec: int8(prec), Assoc: assoc}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return table, nil
+}
+
+func parseAssociativity(text string) (parser.Associativity, error) {
+	switch text {
+	case "left":
+		return parser.LeftAssociative, nil
+	case "right":
+		return parser.RightAssociative, nil
+	}
+	return 0, fmt.Errorf(`invalid associativity %q: expected "left" or "right"`, text)
+}
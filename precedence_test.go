@@ -0,0 +1,83 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/oezg/smart-calculator-go/parser"
+	"github.com/oezg/smart-calculator-go/token"
+)
+
+func writeTable(t *testing.T, contents string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "table.txt")
+	if err := os.WriteFile(path, []byte(contents), 0o644); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+	return path
+}
+
+func TestLoadPrecedenceTable(t *testing.T) {
+	path := writeTable(t, "+ 1 left\n^ 4 right\n\n* 2 left\n")
+	table, err := loadPrecedenceTable(path)
+	if err != nil {
+		t.Fatalf("loadPrecedenceTable error: %v", err)
+	}
+	if info := table[token.PLUS]; info.Prec != 1 || info.Assoc != parser.LeftAssociative {
+		t.Errorf("PLUS = %+v, want {1 left}", info)
+	}
+	if info := table[token.CARET]; info.Prec != 4 || info.Assoc != parser.RightAssociative {
+		t.Errorf("CARET = %+v, want {4 right}", info)
+	}
+}
+
+func TestLoadPrecedenceTableErrors(t *testing.T) {
+	tests := []struct {
+		name     string
+		contents string
+	}{
+		{"too few fields", "+ 1\n"},
+		{"unknown operator", "& 1 left\n"},
+		{"invalid precedence", "+ abc left\n"},
+		{"invalid associativity", "+ 1 sideways\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := writeTable(t, tt.contents)
+			if _, err := loadPrecedenceTable(path); err == nil {
+				t.Fatalf("loadPrecedenceTable(%q) error = nil, want an error", tt.contents)
+			}
+		})
+	}
+}
+
+func TestLoadPrecedenceTableMissingFile(t *testing.T) {
+	if _, err := loadPrecedenceTable(filepath.Join(t.TempDir(), "missing.txt")); err == nil {
+		t.Fatal("loadPrecedenceTable on missing file error = nil, want an error")
+	}
+}
+
+func TestSetPrecedenceSwitchesProfile(t *testing.T) {
+	s := NewSession()
+	s.setPrecedence("flat")
+	value, err := s.evaluateExpression("2 + 3 * 4")
+	if err != nil {
+		t.Fatalf("evaluateExpression error: %v", err)
+	}
+	if value != 20 {
+		t.Errorf("under flat precedence, 2 + 3 * 4 = %d, want 20", value)
+	}
+}
+
+func TestSetPrecedenceUnknownProfile(t *testing.T) {
+	s := NewSession()
+	s.setPrecedence("nonsense")
+	value, err := s.evaluateExpression("2 + 3 * 4")
+	if err != nil {
+		t.Fatalf("evaluateExpression error: %v", err)
+	}
+	if value != 14 {
+		t.Errorf("setPrecedence(%q) changed the active table; 2 + 3 * 4 = %d, want 14 (standard)", "nonsense", value)
+	}
+}
@@ -0,0 +1,198 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/peterh/liner"
+)
+
+// historyFile is where session history persists between runs, mirroring
+// how /write persists variables.
+const historyFile = ".smartcalc_history"
+
+// commandNames lists the "/"-commands completion offers, kept in the same
+// order as the HELP text.
+var commandNames = []string{"clear", "vars", "del", "con", "read", "write", "history", "serve", "prec", "help", "exit"}
+
+// history holds recent input lines, most recent last. It backs /history
+// and is what gets persisted to historyPath; liner.State keeps its own
+// copy for arrow-key recall, seeded from the same file on startup.
+var history []string
+
+func historyPath() string {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return historyFile
+	}
+	return filepath.Join(home, historyFile)
+}
+
+func runRepl(session *Session) {
+	fmt.Println("+-+-+   Welcome to Smart Calculator   */*/*")
+	fmt.Println("Enter a command or start calculation or type '/help'")
+
+	line := liner.NewLiner()
+	defer line.Close()
+	line.SetCtrlCAborts(true)
+	line.SetCompleter(func(text string) []string { return session.completeLine(text) })
+
+	if f, err := os.Open(historyPath()); err == nil {
+		history = append(history, readHistoryLines(f)...)
+		for _, entry := range history {
+			line.AppendHistory(entry)
+		}
+		f.Close()
+	}
+
+	for {
+		statement, err := readStatement(line)
+		if err == io.EOF {
+			fmt.Println("Bye!")
+			break
+		}
+		if err != nil {
+			continue
+		}
+		if strings.TrimSpace(statement) == "" {
+			continue
+		}
+		line.AppendHistory(statement)
+		history = append(history, statement)
+		session.handleCommand(statement)
+		if exiting {
+			break
+		}
+	}
+
+	saveHistory()
+}
+
+// readStatement reads one logical statement, prompting with "... " and
+// reading further lines while the input ends with an unmatched "(" or a
+// trailing binary operator.
+func readStatement(line *liner.State) (string, error) {
+	statement, err := line.Prompt("> ")
+	if err != nil {
+		return "", err
+	}
+	for needsContinuation(statement) {
+		more, err := line.Prompt("... ")
+		if err != nil {
+			return "", err
+		}
+		statement += " " + more
+	}
+	return statement, nil
+}
+
+func needsContinuation(statement string) bool {
+	trimmed := strings.TrimSpace(statement)
+	if trimmed == "" {
+		return false
+	}
+	depth := 0
+	for _, char := range trimmed {
+		switch char {
+		case '(':
+			depth++
+		case ')':
+			depth--
+		}
+	}
+	if depth > 0 {
+		return true
+	}
+	switch trimmed[len(trimmed)-1] {
+	case '+', '-', '*', '/', '%', '^':
+		return true
+	}
+	return false
+}
+
+// completeLine offers "/"-command completions, or known variable and
+// function names pulled from the session when completing an identifier.
+func (s *Session) completeLine(text string) []string {
+	if strings.HasPrefix(text, "/") {
+		var matches []string
+		for _, name := range commandNames {
+			if full := "/" + name; strings.HasPrefix(full, text) {
+				matches = append(matches, full)
+			}
+		}
+		return matches
+	}
+	start := identifierStart(text)
+	prefix, base := text[start:], text[:start]
+	var matches []string
+	for _, name := range s.knownIdentifiers() {
+		if strings.HasPrefix(string(name), prefix) {
+			matches = append(matches, base+string(name))
+		}
+	}
+	return matches
+}
+
+// identifierStart returns the index of the start of the Latin-letter
+// identifier that text ends with, or len(text) if it doesn't end with one.
+func identifierStart(text string) int {
+	i := len(text)
+	for i > 0 && unicode.In(rune(text[i-1]), unicode.Latin) {
+		i--
+	}
+	return i
+}
+
+func (s *Session) knownIdentifiers() []Identifier {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	names := make([]Identifier, 0, len(s.memory)+len(s.functions))
+	for name := range s.memory {
+		names = Push(names, name)
+	}
+	for name := range s.functions {
+		names = Push(names, name)
+	}
+	sort.Slice(names, func(i, j int) bool { return names[i] < names[j] })
+	return names
+}
+
+func printHistory() {
+	for i, entry := range history {
+		fmt.Printf("%4d  %s\n", i+1, entry)
+	}
+}
+
+func clearHistory() {
+	history = nil
+	saveHistory()
+}
+
+func saveHistory() {
+	f, err := os.Create(historyPath())
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	defer f.Close()
+	for _, entry := range history {
+		fmt.Fprintln(f, entry)
+	}
+}
+
+func readHistoryLines(r io.Reader) []string {
+	var lines []string
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		if text := scanner.Text(); text != "" {
+			lines = append(lines, text)
+		}
+	}
+	return lines
+}
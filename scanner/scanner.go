@@ -0,0 +1,131 @@
+// Package scanner implements a lexical scanner for the smart calculator
+// language. Given source text it emits a stream of tokens together with
+// their positions, in the style of go/scanner.
+package scanner
+
+import (
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/oezg/smart-calculator-go/token"
+)
+
+// Scanner tokenizes a fixed source string. It is not safe for concurrent
+// use.
+type Scanner struct {
+	src string
+
+	offset    int  // offset of ch
+	rdOffset  int  // offset of the next rune after ch
+	ch        rune // current character
+	line, col int  // line/col of ch, both 1-based
+	nextLine  int
+	nextCol   int
+}
+
+// New returns a Scanner positioned at the start of src.
+func New(src string) *Scanner {
+	s := &Scanner{src: src, line: 1, col: 0, nextLine: 1, nextCol: 1}
+	s.next()
+	return s
+}
+
+// next advances to the next rune in s.src.
+func (s *Scanner) next() {
+	if s.rdOffset >= len(s.src) {
+		s.offset = len(s.src)
+		s.ch = -1
+		return
+	}
+	s.offset = s.rdOffset
+	s.line, s.col = s.nextLine, s.nextCol
+	r, w := utf8.DecodeRuneInString(s.src[s.rdOffset:])
+	s.ch = r
+	s.rdOffset += w
+	if r == '\n' {
+		s.nextLine++
+		s.nextCol = 1
+	} else {
+		s.nextCol++
+	}
+}
+
+func isDigit(ch rune) bool {
+	return '0' <= ch && ch <= '9'
+}
+
+func isIdentRune(ch rune) bool {
+	return ch >= 0 && unicode.In(ch, unicode.Latin)
+}
+
+func (s *Scanner) skipSpace() {
+	for s.ch == ' ' || s.ch == '\t' || s.ch == '\r' || s.ch == '\n' {
+		s.next()
+	}
+}
+
+func (s *Scanner) pos() token.Pos {
+	return token.Pos{Offset: s.offset, Line: s.line, Col: s.col}
+}
+
+// Scan scans the next token and returns its position, kind and literal text.
+// At the end of the source it returns token.EOF forever.
+func (s *Scanner) Scan() (pos token.Pos, tok token.Token, lit string) {
+	s.skipSpace()
+	pos = s.pos()
+
+	switch ch := s.ch; {
+	case ch == -1:
+		tok = token.EOF
+	case isDigit(ch):
+		tok, lit = token.INT, s.scanNumber()
+		return
+	case isIdentRune(ch):
+		tok, lit = token.IDENT, s.scanIdentifier()
+		return
+	default:
+		s.next()
+		switch ch {
+		case '+':
+			tok = token.PLUS
+		case '-':
+			tok = token.MINUS
+		case '*':
+			tok = token.STAR
+		case '/':
+			tok = token.SLASH
+		case '%':
+			tok = token.PERCENT
+		case '^':
+			tok = token.CARET
+		case '(':
+			tok = token.LPAREN
+		case ')':
+			tok = token.RPAREN
+		case ',':
+			tok = token.COMMA
+		case '=':
+			tok = token.ASSIGN
+		default:
+			tok = token.ILLEGAL
+			lit = string(ch)
+		}
+	}
+	return
+}
+
+func (s *Scanner) scanNumber() string {
+	start := s.offset
+	for isDigit(s.ch) {
+		s.next()
+	}
+	return s.src[start:s.offset]
+}
+
+func (s *Scanner) scanIdentifier() string {
+	start := s.offset
+	for isIdentRune(s.ch) {
+		s.next()
+	}
+	return s.src[start:s.offset]
+}
@@ -0,0 +1,67 @@
+package scanner
+
+import (
+	"testing"
+
+	"github.com/oezg/smart-calculator-go/token"
+)
+
+func TestScan(t *testing.T) {
+	tests := []struct {
+		src  string
+		toks []token.Token
+		lits []string
+	}{
+		{"", []token.Token{token.EOF}, []string{""}},
+		{"42", []token.Token{token.INT, token.EOF}, []string{"42", ""}},
+		{"abs", []token.Token{token.IDENT, token.EOF}, []string{"abs", ""}},
+		{
+			"2 + x * (3 - 1)",
+			[]token.Token{
+				token.INT, token.PLUS, token.IDENT, token.STAR, token.LPAREN,
+				token.INT, token.MINUS, token.INT, token.RPAREN, token.EOF,
+			},
+			[]string{"2", "", "x", "", "", "3", "", "1", "", ""},
+		},
+		{"f(x, y) = x^y", []token.Token{
+			token.IDENT, token.LPAREN, token.IDENT, token.COMMA, token.IDENT,
+			token.RPAREN, token.ASSIGN, token.IDENT, token.CARET, token.IDENT, token.EOF,
+		}, nil},
+		{"@", []token.Token{token.ILLEGAL, token.EOF}, []string{"@", ""}},
+	}
+	for _, tt := range tests {
+		s := New(tt.src)
+		for i, want := range tt.toks {
+			_, tok, lit := s.Scan()
+			if tok != want {
+				t.Fatalf("%q: token %d = %v, want %v", tt.src, i, tok, want)
+			}
+			if tt.lits != nil && lit != tt.lits[i] {
+				t.Fatalf("%q: literal %d = %q, want %q", tt.src, i, lit, tt.lits[i])
+			}
+		}
+	}
+}
+
+func TestScanEOFIsSticky(t *testing.T) {
+	s := New("1")
+	s.Scan()
+	for i := 0; i < 3; i++ {
+		if _, tok, _ := s.Scan(); tok != token.EOF {
+			t.Fatalf("Scan() after EOF = %v, want EOF", tok)
+		}
+	}
+}
+
+func TestScanPositions(t *testing.T) {
+	pos, _, _ := New("  12").Scan()
+	if pos.Line != 1 || pos.Col != 3 {
+		t.Fatalf("pos = %v, want line 1 col 3", pos)
+	}
+	s := New("1\n  2")
+	s.Scan()
+	pos, _, _ = s.Scan()
+	if pos.Line != 2 || pos.Col != 3 {
+		t.Fatalf("pos = %v, want line 2 col 3", pos)
+	}
+}
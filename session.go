@@ -0,0 +1,28 @@
+package main
+
+import (
+	"sync"
+
+	"github.com/oezg/smart-calculator-go/parser"
+)
+
+// Session holds one calculator's state: its variables, user-defined
+// functions, and active operator precedence table. The REPL keeps a single
+// Session for the process lifetime; /serve (see http.go) exposes that same
+// Session over HTTP in a separate goroutine, so every method that touches
+// memory, functions, or table — REPL-side or HTTP-side — takes mu first.
+type Session struct {
+	mu        sync.Mutex
+	memory    map[Identifier]Value
+	functions map[Identifier]Function
+	table     parser.PrecedenceTable
+}
+
+// NewSession returns an empty Session, ready to use.
+func NewSession() *Session {
+	return &Session{
+		memory:    make(map[Identifier]Value),
+		functions: make(map[Identifier]Function),
+		table:     parser.StandardTable,
+	}
+}
@@ -0,0 +1,87 @@
+// Package token defines the lexical tokens of the smart calculator language
+// and the positions at which they occur, mirroring the shape of go/token.
+package token
+
+import "fmt"
+
+// Token identifies the lexical class of a lexeme.
+type Token int
+
+const (
+	ILLEGAL Token = iota
+	EOF
+
+	INT   // 123
+	IDENT // x, abs
+
+	PLUS    // +
+	MINUS   // -
+	STAR    // *
+	SLASH   // /
+	PERCENT // %
+	CARET   // ^
+
+	LPAREN // (
+	RPAREN // )
+	COMMA  // ,
+	ASSIGN // =
+)
+
+var tokens = [...]string{
+	ILLEGAL: "ILLEGAL",
+	EOF:     "EOF",
+	INT:     "INT",
+	IDENT:   "IDENT",
+	PLUS:    "+",
+	MINUS:   "-",
+	STAR:    "*",
+	SLASH:   "/",
+	PERCENT: "%",
+	CARET:   "^",
+	LPAREN:  "(",
+	RPAREN:  ")",
+	COMMA:   ",",
+	ASSIGN:  "=",
+}
+
+// String returns the textual representation of tok.
+func (tok Token) String() string {
+	if tok < 0 || int(tok) >= len(tokens) {
+		return "UNKNOWN"
+	}
+	return tokens[tok]
+}
+
+// IsOperator reports whether tok is one of the binary/unary arithmetic
+// operators.
+func (tok Token) IsOperator() bool {
+	switch tok {
+	case PLUS, MINUS, STAR, SLASH, PERCENT, CARET:
+		return true
+	}
+	return false
+}
+
+// Lookup returns the operator Token whose symbol is sym (e.g. "^" -> CARET),
+// and whether one was found.
+func Lookup(sym string) (Token, bool) {
+	for tok := PLUS; tok <= CARET; tok++ {
+		if tokens[tok] == sym {
+			return tok, true
+		}
+	}
+	return ILLEGAL, false
+}
+
+// Pos records the location of a token in the source: a byte offset plus the
+// 1-based line and column it falls on.
+type Pos struct {
+	Offset int
+	Line   int
+	Col    int
+}
+
+// String formats p as "line:col".
+func (p Pos) String() string {
+	return fmt.Sprintf("%d:%d", p.Line, p.Col)
+}
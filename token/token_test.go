@@ -0,0 +1,49 @@
+package token
+
+import "testing"
+
+func TestTokenString(t *testing.T) {
+	tests := []struct {
+		tok  Token
+		want string
+	}{
+		{PLUS, "+"},
+		{CARET, "^"},
+		{IDENT, "IDENT"},
+		{Token(999), "UNKNOWN"},
+	}
+	for _, tt := range tests {
+		if got := tt.tok.String(); got != tt.want {
+			t.Errorf("Token(%d).String() = %q, want %q", tt.tok, got, tt.want)
+		}
+	}
+}
+
+func TestIsOperator(t *testing.T) {
+	for _, tok := range []Token{PLUS, MINUS, STAR, SLASH, PERCENT, CARET} {
+		if !tok.IsOperator() {
+			t.Errorf("%v.IsOperator() = false, want true", tok)
+		}
+	}
+	for _, tok := range []Token{LPAREN, RPAREN, COMMA, ASSIGN, IDENT, INT, EOF, ILLEGAL} {
+		if tok.IsOperator() {
+			t.Errorf("%v.IsOperator() = true, want false", tok)
+		}
+	}
+}
+
+func TestLookup(t *testing.T) {
+	if tok, ok := Lookup("^"); !ok || tok != CARET {
+		t.Errorf(`Lookup("^") = %v, %v, want CARET, true`, tok, ok)
+	}
+	if _, ok := Lookup("@"); ok {
+		t.Error(`Lookup("@") ok = true, want false`)
+	}
+}
+
+func TestPosString(t *testing.T) {
+	p := Pos{Line: 2, Col: 5}
+	if got, want := p.String(), "2:5"; got != want {
+		t.Errorf("Pos.String() = %q, want %q", got, want)
+	}
+}